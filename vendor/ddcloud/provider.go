@@ -0,0 +1,20 @@
+package ddcloud
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// Provider creates the ddcloud Terraform provider.
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: providerSchema(),
+
+		ResourcesMap: map[string]*schema.Resource{
+			"ddcloud_firewall_rule":     resourceFirewallRule(),
+			"ddcloud_firewall_policy":   resourceFirewallPolicy(),
+			"ddcloud_firewall_rule_set": resourceFirewallRuleSet(),
+		},
+
+		ConfigureFunc: configureProvider,
+	}
+}
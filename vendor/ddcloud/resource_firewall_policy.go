@@ -0,0 +1,516 @@
+package ddcloud
+
+import (
+	"fmt"
+	"github.com/DimensionDataResearch/go-dd-cloud-compute/compute"
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"strings"
+)
+
+const (
+	resourceKeyFirewallPolicyNetworkDomainID        = "networkdomain"
+	resourceKeyFirewallPolicyRule                   = "rule"
+	resourceKeyFirewallPolicyRuleID                 = "id"
+	resourceKeyFirewallPolicyRuleName               = "name"
+	resourceKeyFirewallPolicyRuleAction             = "action"
+	resourceKeyFirewallPolicyRuleEnabled            = "enabled"
+	resourceKeyFirewallPolicyRuleIPVersion          = "ip_version"
+	resourceKeyFirewallPolicyRuleProtocol           = "protocol"
+	resourceKeyFirewallPolicyRuleSourceAddress      = "source_address"
+	resourceKeyFirewallPolicyRuleSourceNetwork      = "source_network"
+	resourceKeyFirewallPolicyRuleSourcePort         = "source_port"
+	resourceKeyFirewallPolicyRuleDestinationAddress = "destination_address"
+	resourceKeyFirewallPolicyRuleDestinationNetwork = "destination_network"
+	resourceKeyFirewallPolicyRuleDestinationPort    = "destination_port"
+)
+
+// resourceFirewallPolicy defines the ddcloud_firewall_policy resource, which owns an ordered group of firewall rules
+// within a network domain. It replaces the per-rule placement / placement_relative_to mechanism used by
+// ddcloud_firewall_rule with a single resource that manages the entire ACL as one unit.
+func resourceFirewallPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallPolicyCreate,
+		Read:   resourceFirewallPolicyRead,
+		Update: resourceFirewallPolicyUpdate,
+		Delete: resourceFirewallPolicyDelete,
+
+		Schema: map[string]*schema.Schema{
+			resourceKeyFirewallPolicyNetworkDomainID: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Required:    true,
+				Description: "The Id of the network domain whose firewall ACL is managed by this policy",
+			},
+			resourceKeyFirewallPolicyRule: &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				Description: "The ordered list of firewall rules that make up this policy",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						resourceKeyFirewallPolicyRuleID: &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The Id of the underlying firewall rule",
+						},
+						resourceKeyFirewallPolicyRuleName: &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "A name for the firewall rule",
+						},
+						resourceKeyFirewallPolicyRuleAction: &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The action performed by the firewall rule",
+						},
+						resourceKeyFirewallPolicyRuleEnabled: &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Is the firewall rule enabled",
+						},
+						resourceKeyFirewallPolicyRuleIPVersion: &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The IP version to which the firewall rule applies",
+						},
+						resourceKeyFirewallPolicyRuleProtocol: &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The protocol to which the rule applies",
+						},
+						resourceKeyFirewallPolicyRuleSourceAddress: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The source IP address to be matched by the rule",
+						},
+						resourceKeyFirewallPolicyRuleSourceNetwork: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The source IP network to be matched by the rule",
+						},
+						resourceKeyFirewallPolicyRuleSourcePort: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The source port (or port range, e.g. '8000-8099') to be matched by the rule",
+						},
+						resourceKeyFirewallPolicyRuleDestinationAddress: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The destination IP address to be matched by the rule",
+						},
+						resourceKeyFirewallPolicyRuleDestinationNetwork: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The destination IP network to be matched by the rule",
+						},
+						resourceKeyFirewallPolicyRuleDestinationPort: &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The destination port (or port range, e.g. '8000-8099') to be matched by the rule",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Create a firewall policy resource (and the firewall rules it owns, in order).
+func resourceFirewallPolicyCreate(data *schema.ResourceData, provider interface{}) error {
+	networkDomainID := data.Get(resourceKeyFirewallPolicyNetworkDomainID).(string)
+
+	log.Printf("Create firewall policy for network domain '%s'.", networkDomainID)
+
+	providerState := provider.(*providerState)
+	apiClient := providerState.Client()
+
+	domainLock := providerState.GetDomainLock(networkDomainID, "resourceFirewallPolicyCreate(networkDomainID = '%s')", networkDomainID)
+	domainLock.Lock()
+	defer domainLock.Unlock()
+
+	rules := data.Get(resourceKeyFirewallPolicyRule).([]interface{})
+
+	// Record the Id of each rule as soon as it's created (rather than only once the whole loop succeeds), so that
+	// a failure partway through still leaves Terraform tracking every rule that was actually created in CloudControl.
+	data.SetId(networkDomainID)
+
+	var previousRuleName string
+	for index, ruleData := range rules {
+		ruleConfig := ruleData.(map[string]interface{})
+
+		placement := firstOrAfter(previousRuleName, index)
+
+		configuration, err := buildFirewallPolicyRuleConfiguration(ruleConfig, networkDomainID, placement)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Create firewall rule '%s' (position %d) for policy in network domain '%s'.", configuration.Name, index, networkDomainID)
+
+		ruleID, err := apiClient.CreateFirewallRule(*configuration)
+		if err != nil {
+			return err
+		}
+
+		ruleConfig[resourceKeyFirewallPolicyRuleID] = ruleID
+		rules[index] = ruleConfig
+		data.Set(resourceKeyFirewallPolicyRule, rules)
+
+		_, err = apiClient.WaitForDeploy(compute.ResourceTypeFirewallRule, ruleID, resourceCreateTimeoutFirewallRule)
+		if err != nil {
+			return err
+		}
+
+		previousRuleName = configuration.Name
+	}
+
+	return nil
+}
+
+// Read a firewall policy resource, refreshing the state of the rules it owns.
+func resourceFirewallPolicyRead(data *schema.ResourceData, provider interface{}) error {
+	networkDomainID := data.Id()
+
+	log.Printf("Read firewall policy for network domain '%s'.", networkDomainID)
+
+	apiClient := provider.(*providerState).Client()
+
+	rules := data.Get(resourceKeyFirewallPolicyRule).([]interface{})
+	existingRules := make([]interface{}, 0, len(rules))
+	for _, ruleData := range rules {
+		ruleConfig := ruleData.(map[string]interface{})
+
+		ruleID, ok := ruleConfig[resourceKeyFirewallPolicyRuleID].(string)
+		if !ok || ruleID == "" {
+			continue
+		}
+
+		rule, err := apiClient.GetFirewallRule(ruleID)
+		if err != nil {
+			return err
+		}
+		if rule == nil {
+			log.Printf("Firewall rule '%s' (part of policy for network domain '%s') has been deleted.", ruleID, networkDomainID)
+
+			continue
+		}
+
+		ruleConfig[resourceKeyFirewallPolicyRuleEnabled] = rule.Enabled
+		existingRules = append(existingRules, ruleConfig)
+	}
+
+	if len(existingRules) == 0 {
+		log.Printf("Firewall policy for network domain '%s' has been deleted.", networkDomainID)
+
+		data.SetId("")
+
+		return nil
+	}
+
+	data.Set(resourceKeyFirewallPolicyRule, existingRules)
+
+	return nil
+}
+
+// Update a firewall policy resource, recreating only the rules whose configuration changed and re-anchoring
+// everything so the resulting server-side order matches the configuration exactly.
+func resourceFirewallPolicyUpdate(data *schema.ResourceData, provider interface{}) error {
+	networkDomainID := data.Id()
+
+	log.Printf("Update firewall policy for network domain '%s'.", networkDomainID)
+
+	providerState := provider.(*providerState)
+	apiClient := providerState.Client()
+
+	domainLock := providerState.GetDomainLock(networkDomainID, "resourceFirewallPolicyUpdate(networkDomainID = '%s')", networkDomainID)
+	domainLock.Lock()
+	defer domainLock.Unlock()
+
+	oldValue, newValue := data.GetChange(resourceKeyFirewallPolicyRule)
+	oldRules := oldValue.([]interface{})
+	newRules := newValue.([]interface{})
+
+	existingRuleIDsByName := make(map[string]string)
+	for _, ruleData := range oldRules {
+		ruleConfig := ruleData.(map[string]interface{})
+		if ruleID, ok := ruleConfig[resourceKeyFirewallPolicyRuleID].(string); ok && ruleID != "" {
+			existingRuleIDsByName[ruleConfig[resourceKeyFirewallPolicyRuleName].(string)] = ruleID
+		}
+	}
+
+	var previousRuleName string
+	for index, ruleData := range newRules {
+		ruleConfig := ruleData.(map[string]interface{})
+		ruleName := ruleConfig[resourceKeyFirewallPolicyRuleName].(string)
+
+		placement := firstOrAfter(previousRuleName, index)
+
+		configuration, err := buildFirewallPolicyRuleConfiguration(ruleConfig, networkDomainID, placement)
+		if err != nil {
+			return err
+		}
+
+		existingRuleID, isExisting := existingRuleIDsByName[ruleName]
+		if isExisting && firewallPolicyRuleUnchanged(oldRules, ruleConfig) {
+			ruleConfig[resourceKeyFirewallPolicyRuleID] = existingRuleID
+			newRules[index] = ruleConfig
+			data.Set(resourceKeyFirewallPolicyRule, newRules)
+
+			err = relocateFirewallPolicyRule(apiClient, networkDomainID, ruleName, placement)
+			if err != nil {
+				return err
+			}
+
+			previousRuleName = ruleName
+
+			continue
+		}
+
+		if isExisting {
+			log.Printf("Recreating firewall rule '%s' (configuration changed) for policy in network domain '%s'.", ruleName, networkDomainID)
+
+			err = deleteFirewallPolicyRule(apiClient, existingRuleID)
+			if err != nil {
+				return err
+			}
+
+			// The old rule Id is gone; until the replacement is created, Terraform should no longer believe it owns it.
+			delete(existingRuleIDsByName, ruleName)
+			ruleConfig[resourceKeyFirewallPolicyRuleID] = ""
+			newRules[index] = ruleConfig
+			data.Set(resourceKeyFirewallPolicyRule, newRules)
+		}
+
+		ruleID, err := apiClient.CreateFirewallRule(*configuration)
+		if err != nil {
+			return err
+		}
+
+		ruleConfig[resourceKeyFirewallPolicyRuleID] = ruleID
+		newRules[index] = ruleConfig
+		data.Set(resourceKeyFirewallPolicyRule, newRules)
+
+		_, err = apiClient.WaitForDeploy(compute.ResourceTypeFirewallRule, ruleID, resourceCreateTimeoutFirewallRule)
+		if err != nil {
+			return err
+		}
+
+		previousRuleName = ruleName
+	}
+
+	newRuleNames := make(map[string]bool)
+	for _, ruleData := range newRules {
+		ruleConfig := ruleData.(map[string]interface{})
+		newRuleNames[ruleConfig[resourceKeyFirewallPolicyRuleName].(string)] = true
+	}
+	for name, ruleID := range existingRuleIDsByName {
+		if newRuleNames[name] {
+			continue
+		}
+
+		log.Printf("Removing firewall rule '%s' (no longer present in policy) for network domain '%s'.", name, networkDomainID)
+
+		err := deleteFirewallPolicyRule(apiClient, ruleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	data.Set(resourceKeyFirewallPolicyRule, newRules)
+
+	return nil
+}
+
+// relocateFirewallPolicyRule re-anchors an existing, content-unchanged firewall rule to the given placement, so
+// that a rule which has only moved position in the policy's rule list is reordered server-side to match.
+func relocateFirewallPolicyRule(apiClient *compute.Client, networkDomainID string, ruleName string, placement compute.FirewallRulePlacement) error {
+	log.Printf("Relocating firewall rule '%s' for policy in network domain '%s' (position '%s').", ruleName, networkDomainID, placement.Position)
+
+	return apiClient.RelocateFirewallRule(networkDomainID, ruleName, placement)
+}
+
+// Delete a firewall policy resource, along with all the firewall rules it owns.
+func resourceFirewallPolicyDelete(data *schema.ResourceData, provider interface{}) error {
+	networkDomainID := data.Id()
+
+	log.Printf("Delete firewall policy for network domain '%s'.", networkDomainID)
+
+	providerState := provider.(*providerState)
+	apiClient := providerState.Client()
+
+	domainLock := providerState.GetDomainLock(networkDomainID, "resourceFirewallPolicyDelete(networkDomainID = '%s')", networkDomainID)
+	domainLock.Lock()
+	defer domainLock.Unlock()
+
+	rules := data.Get(resourceKeyFirewallPolicyRule).([]interface{})
+	for _, ruleData := range rules {
+		ruleConfig := ruleData.(map[string]interface{})
+
+		ruleID, ok := ruleConfig[resourceKeyFirewallPolicyRuleID].(string)
+		if !ok || ruleID == "" {
+			continue
+		}
+
+		err := deleteFirewallPolicyRule(apiClient, ruleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteFirewallPolicyRule(apiClient *compute.Client, ruleID string) error {
+	err := apiClient.DeleteFirewallRule(ruleID)
+	if err != nil {
+		return err
+	}
+
+	return apiClient.WaitForDelete(compute.ResourceTypeFirewallRule, ruleID, resourceDeleteTimeoutFirewallRule)
+}
+
+// firstOrAfter computes the FirewallRulePlacement for the rule at the given (0-based) position in a policy's rule
+// list, anchoring it directly after the previous rule so the resulting server-side order matches the list order.
+func firstOrAfter(previousRuleName string, index int) compute.FirewallRulePlacement {
+	if index == 0 {
+		return compute.FirewallRulePlacement{
+			Position: "FIRST",
+		}
+	}
+
+	return compute.FirewallRulePlacement{
+		Position:           "AFTER",
+		RelativeToRuleName: &previousRuleName,
+	}
+}
+
+// firewallPolicyRuleUnchanged determines whether the rule named by newRule is present, unchanged, in oldRules.
+func firewallPolicyRuleUnchanged(oldRules []interface{}, newRule map[string]interface{}) bool {
+	for _, ruleData := range oldRules {
+		oldRule := ruleData.(map[string]interface{})
+		if oldRule[resourceKeyFirewallPolicyRuleName] != newRule[resourceKeyFirewallPolicyRuleName] {
+			continue
+		}
+
+		return oldRule[resourceKeyFirewallPolicyRuleAction] == newRule[resourceKeyFirewallPolicyRuleAction] &&
+			oldRule[resourceKeyFirewallPolicyRuleEnabled] == newRule[resourceKeyFirewallPolicyRuleEnabled] &&
+			oldRule[resourceKeyFirewallPolicyRuleIPVersion] == newRule[resourceKeyFirewallPolicyRuleIPVersion] &&
+			oldRule[resourceKeyFirewallPolicyRuleProtocol] == newRule[resourceKeyFirewallPolicyRuleProtocol] &&
+			oldRule[resourceKeyFirewallPolicyRuleSourceAddress] == newRule[resourceKeyFirewallPolicyRuleSourceAddress] &&
+			oldRule[resourceKeyFirewallPolicyRuleSourceNetwork] == newRule[resourceKeyFirewallPolicyRuleSourceNetwork] &&
+			oldRule[resourceKeyFirewallPolicyRuleSourcePort] == newRule[resourceKeyFirewallPolicyRuleSourcePort] &&
+			oldRule[resourceKeyFirewallPolicyRuleDestinationAddress] == newRule[resourceKeyFirewallPolicyRuleDestinationAddress] &&
+			oldRule[resourceKeyFirewallPolicyRuleDestinationNetwork] == newRule[resourceKeyFirewallPolicyRuleDestinationNetwork] &&
+			oldRule[resourceKeyFirewallPolicyRuleDestinationPort] == newRule[resourceKeyFirewallPolicyRuleDestinationPort]
+	}
+
+	return false
+}
+
+// buildFirewallPolicyRuleConfiguration translates a single rule block from a ddcloud_firewall_policy resource into
+// the CloudControl firewall rule configuration used to create or recreate it.
+func buildFirewallPolicyRuleConfiguration(ruleConfig map[string]interface{}, networkDomainID string, placement compute.FirewallRulePlacement) (*compute.FirewallRuleConfiguration, error) {
+	name := ruleConfig[resourceKeyFirewallPolicyRuleName].(string)
+
+	configuration := &compute.FirewallRuleConfiguration{
+		Name:            name,
+		Action:          normalizeFirewallRuleAction(ruleConfig[resourceKeyFirewallPolicyRuleAction].(string)),
+		Placement:       placement,
+		Enabled:         ruleConfig[resourceKeyFirewallPolicyRuleEnabled].(bool),
+		NetworkDomainID: networkDomainID,
+		IPVersion: strings.ToUpper(
+			ruleConfig[resourceKeyFirewallPolicyRuleIPVersion].(string),
+		),
+		Protocol: strings.ToUpper(
+			ruleConfig[resourceKeyFirewallPolicyRuleProtocol].(string),
+		),
+	}
+
+	sourceAddress := stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleSourceAddress].(string))
+	sourceNetwork := stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleSourceNetwork].(string))
+	sourcePortBegin, sourcePortEnd, err := parseFirewallPort(
+		stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleSourcePort].(string)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sourceAddress != nil && sourceNetwork != nil:
+		return nil, fmt.Errorf("rule '%s' specifies both '%s' and '%s'", name, resourceKeyFirewallPolicyRuleSourceAddress, resourceKeyFirewallPolicyRuleSourceNetwork)
+
+	case sourceAddress != nil && sourcePortEnd != nil:
+		configuration.MatchSourceAddressAndPortRange(*sourceAddress, *sourcePortBegin, *sourcePortEnd)
+
+	case sourceAddress != nil:
+		configuration.MatchSourceAddressAndPort(*sourceAddress, sourcePortBegin)
+
+	case sourceNetwork != nil:
+		baseAddress, prefixSize, ok := parseNetworkAndPrefix(*sourceNetwork)
+		if !ok {
+			return nil, fmt.Errorf("'%s' for rule '%s' is invalid (must be 'BaseAddress/PrefixSize')", *sourceNetwork, name)
+		}
+
+		if sourcePortEnd != nil {
+			configuration.MatchSourceNetworkAndPortRange(baseAddress, prefixSize, *sourcePortBegin, *sourcePortEnd)
+		} else {
+			configuration.MatchSourceNetworkAndPort(baseAddress, prefixSize, sourcePortBegin)
+		}
+
+	case sourcePortEnd != nil:
+		configuration.MatchAnySourceAddressWithPortRange(*sourcePortBegin, *sourcePortEnd)
+
+	default:
+		configuration.MatchAnySourceAddress(sourcePortBegin)
+	}
+
+	destinationAddress := stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleDestinationAddress].(string))
+	destinationNetwork := stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleDestinationNetwork].(string))
+	destinationPortBegin, destinationPortEnd, err := parseFirewallPort(
+		stringPtrOrNil(ruleConfig[resourceKeyFirewallPolicyRuleDestinationPort].(string)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case destinationAddress != nil && destinationNetwork != nil:
+		return nil, fmt.Errorf("rule '%s' specifies both '%s' and '%s'", name, resourceKeyFirewallPolicyRuleDestinationAddress, resourceKeyFirewallPolicyRuleDestinationNetwork)
+
+	case destinationAddress != nil && destinationPortEnd != nil:
+		configuration.MatchDestinationAddressAndPortRange(*destinationAddress, *destinationPortBegin, *destinationPortEnd)
+
+	case destinationAddress != nil:
+		configuration.MatchDestinationAddressAndPort(*destinationAddress, destinationPortBegin)
+
+	case destinationNetwork != nil:
+		baseAddress, prefixSize, ok := parseNetworkAndPrefix(*destinationNetwork)
+		if !ok {
+			return nil, fmt.Errorf("'%s' for rule '%s' is invalid (must be 'BaseAddress/PrefixSize')", *destinationNetwork, name)
+		}
+
+		if destinationPortEnd != nil {
+			configuration.MatchDestinationNetworkAndPortRange(baseAddress, prefixSize, *destinationPortBegin, *destinationPortEnd)
+		} else {
+			configuration.MatchDestinationNetworkAndPort(baseAddress, prefixSize, destinationPortBegin)
+		}
+
+	case destinationPortEnd != nil:
+		configuration.MatchAnyDestinationAddressWithPortRange(*destinationPortBegin, *destinationPortEnd)
+
+	default:
+		configuration.MatchAnyDestinationAddress(destinationPortBegin)
+	}
+
+	return configuration, nil
+}
+
+// stringPtrOrNil returns nil for an empty string, and a pointer to value otherwise.
+func stringPtrOrNil(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
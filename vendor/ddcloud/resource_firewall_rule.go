@@ -22,9 +22,13 @@ const (
 	resourceKeyFirewallRuleSourceAddress               = "source_address"
 	resourceKeyFirewallRuleSourceNetwork               = "source_network"
 	resourceKeyFirewallRuleSourcePort                  = "source_port"
+	resourceKeyFirewallRuleSourcePorts                 = "source_ports"
 	resourceKeyFirewallRuleDestinationAddress          = "destination_address"
 	resourceKeyFirewallRuleDestinationNetwork          = "destination_network"
 	resourceKeyFirewallRuleDestinationPort             = "destination_port"
+	resourceKeyFirewallRuleDestinationPorts            = "destination_ports"
+	resourceKeyFirewallRuleICMPType                    = "icmp_type"
+	resourceKeyFirewallRuleICMPCode                    = "icmp_code"
 	resourceCreateTimeoutFirewallRule                  = 30 * time.Minute
 	resourceUpdateTimeoutFirewallRule                  = 10 * time.Minute
 	resourceDeleteTimeoutFirewallRule                  = 15 * time.Minute
@@ -40,6 +44,10 @@ func resourceFirewallRule() *schema.Resource {
 		Update: resourceFirewallRuleUpdate,
 		Delete: resourceFirewallRuleDelete,
 
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
 		Schema: map[string]*schema.Schema{
 			resourceKeyFirewallRuleNetworkDomainID: &schema.Schema{
 				Type:        schema.TypeString,
@@ -91,10 +99,11 @@ func resourceFirewallRule() *schema.Resource {
 				Description: "The IP version to which the firewall rule applies",
 			},
 			resourceKeyFirewallRuleProtocol: &schema.Schema{
-				Type:        schema.TypeString,
-				ForceNew:    true,
-				Required:    true,
-				Description: "The protocol to which the rule applies",
+				Type:         schema.TypeString,
+				ForceNew:     true,
+				Required:     true,
+				Description:  "The protocol to which the rule applies",
+				ValidateFunc: validateFirewallRuleProtocol,
 			},
 			resourceKeyFirewallRuleSourceAddress: &schema.Schema{
 				Type:        schema.TypeString,
@@ -118,7 +127,23 @@ func resourceFirewallRule() *schema.Resource {
 				Type:        schema.TypeString,
 				ForceNew:    true,
 				Optional:    true,
-				Description: "The source port to be matched by the rule",
+				Description: "The source port (or port range, e.g. '8000-8099') to be matched by the rule",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSourcePorts,
+				},
+			},
+			resourceKeyFirewallRuleSourcePorts: &schema.Schema{
+				Type:        schema.TypeList,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The list of source ports to be matched by the rule",
+				Elem: &schema.Schema{
+					Type:         schema.TypeInt,
+					ValidateFunc: validateFirewallRulePortNumber,
+				},
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSourcePort,
+				},
 			},
 			resourceKeyFirewallRuleDestinationAddress: &schema.Schema{
 				Type:        schema.TypeString,
@@ -142,7 +167,39 @@ func resourceFirewallRule() *schema.Resource {
 				Type:        schema.TypeString,
 				ForceNew:    true,
 				Optional:    true,
-				Description: "The destination port to be matched by the rule",
+				Description: "The destination port (or port range, e.g. '8000-8099') to be matched by the rule",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleDestinationPorts,
+				},
+			},
+			resourceKeyFirewallRuleDestinationPorts: &schema.Schema{
+				Type:        schema.TypeList,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The list of destination ports to be matched by the rule",
+				Elem: &schema.Schema{
+					Type:         schema.TypeInt,
+					ValidateFunc: validateFirewallRulePortNumber,
+				},
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleDestinationPort,
+				},
+			},
+			resourceKeyFirewallRuleICMPType: &schema.Schema{
+				Type:         schema.TypeInt,
+				ForceNew:     true,
+				Optional:     true,
+				Default:      -1,
+				Description:  "The ICMP type to be matched by the rule ('icmp' / 'icmpv6' protocol only; -1 matches any type)",
+				ValidateFunc: validateFirewallRuleICMPTypeOrCode,
+			},
+			resourceKeyFirewallRuleICMPCode: &schema.Schema{
+				Type:         schema.TypeInt,
+				ForceNew:     true,
+				Optional:     true,
+				Default:      -1,
+				Description:  "The ICMP code to be matched by the rule ('icmp' / 'icmpv6' protocol only; -1 matches any code; ignored unless icmp_type is also set)",
+				ValidateFunc: validateFirewallRuleICMPTypeOrCode,
 			},
 		},
 	}
@@ -154,6 +211,10 @@ func resourceFirewallRuleCreate(data *schema.ResourceData, provider interface{})
 
 	propertyHelper := propertyHelper(data)
 
+	protocol := strings.ToUpper(
+		data.Get(resourceKeyFirewallRuleProtocol).(string),
+	)
+
 	configuration := &compute.FirewallRuleConfiguration{
 		Name: data.Get(resourceKeyFirewallRuleName).(string),
 		Action: normalizeFirewallRuleAction(
@@ -172,13 +233,18 @@ func resourceFirewallRuleCreate(data *schema.ResourceData, provider interface{})
 		IPVersion: strings.ToUpper(
 			data.Get(resourceKeyFirewallRuleIPVersion).(string),
 		),
-		Protocol: strings.ToUpper(
-			data.Get(resourceKeyFirewallRuleProtocol).(string),
-		),
+		Protocol: protocol,
 	}
 
 	configuration.Action = data.Get(resourceKeyFirewallRuleAction).(string)
 
+	if isICMPProtocol(protocol) {
+		err = validateNoPortsForICMP(propertyHelper)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = configureSourceScope(propertyHelper, configuration)
 	if err != nil {
 		return err
@@ -188,6 +254,10 @@ func resourceFirewallRuleCreate(data *schema.ResourceData, provider interface{})
 		return err
 	}
 
+	if isICMPProtocol(protocol) {
+		configureICMP(propertyHelper, configuration)
+	}
+
 	log.Printf("Create firewall rule '%s' in network domain '%s'.", configuration.Name, configuration.NetworkDomainID)
 	log.Printf("Firewall rule configuration: '%#v'", configuration)
 
@@ -231,11 +301,74 @@ func resourceFirewallRuleRead(data *schema.ResourceData, provider interface{}) e
 		return nil
 	}
 
+	data.Set(resourceKeyFirewallRuleNetworkDomainID, rule.NetworkDomainID)
+	data.Set(resourceKeyFirewallRuleName, rule.Name)
+	data.Set(resourceKeyFirewallRuleAction, normalizeFirewallRuleAction(rule.Action))
 	data.Set(resourceKeyFirewallRuleEnabled, rule.Enabled)
+	data.Set(resourceKeyFirewallRuleIPVersion, rule.IPVersion)
+	data.Set(resourceKeyFirewallRuleProtocol, rule.Protocol)
+
+	if isICMPProtocol(rule.Protocol) {
+		icmpType := -1
+		if rule.ICMPType != nil {
+			icmpType = *rule.ICMPType
+		}
+		data.Set(resourceKeyFirewallRuleICMPType, icmpType)
+
+		icmpCode := -1
+		if rule.ICMPCode != nil {
+			icmpCode = *rule.ICMPCode
+		}
+		data.Set(resourceKeyFirewallRuleICMPCode, icmpCode)
+	}
+
+	readFirewallRuleScope(data,
+		resourceKeyFirewallRuleSourceAddress, resourceKeyFirewallRuleSourceNetwork, resourceKeyFirewallRuleSourcePort,
+		rule.Source,
+	)
+	readFirewallRuleScope(data,
+		resourceKeyFirewallRuleDestinationAddress, resourceKeyFirewallRuleDestinationNetwork, resourceKeyFirewallRuleDestinationPort,
+		rule.Destination,
+	)
 
 	return nil
 }
 
+// readFirewallRuleScope populates the address / network / port schema keys for one side (source or destination)
+// of a firewall rule from the API's scope representation, reconstructing 'BaseAddress/PrefixSize' form for
+// network matches from the API's separate address and prefix-size fields.
+//
+// CloudControl's firewall rule scope only ever models a single port or a single contiguous range
+// (compute.FirewallRulePort has no list equivalent), so source_ports/destination_ports have no server-side
+// counterpart to reconstruct here and are deliberately left untouched, like any other attribute Read can't
+// refresh - resetting them would fight the ForceNew on every apply of a rule created from a port list.
+func readFirewallRuleScope(data *schema.ResourceData, addressKey string, networkKey string, portKey string, scope compute.FirewallRuleScope) {
+	switch {
+	case scope.Address != nil:
+		data.Set(addressKey, *scope.Address)
+		data.Set(networkKey, "")
+
+	case scope.Network != nil:
+		data.Set(networkKey, fmt.Sprintf("%s/%d", scope.Network.Address, scope.Network.PrefixSize))
+		data.Set(addressKey, "")
+
+	default:
+		data.Set(addressKey, "")
+		data.Set(networkKey, "")
+	}
+
+	switch {
+	case scope.Port == nil:
+		data.Set(portKey, matchAny)
+
+	case scope.Port.End != nil:
+		data.Set(portKey, fmt.Sprintf("%d-%d", scope.Port.Begin, *scope.Port.End))
+
+	default:
+		data.Set(portKey, strconv.Itoa(scope.Port.Begin))
+	}
+}
+
 // Update a firewall rule resource.
 func resourceFirewallRuleUpdate(data *schema.ResourceData, provider interface{}) error {
 	id := data.Id()
@@ -297,15 +430,29 @@ func resourceFirewallRuleDelete(data *schema.ResourceData, provider interface{})
 func configureSourceScope(propertyHelper resourcePropertyHelper, configuration *compute.FirewallRuleConfiguration) error {
 	sourceAddress := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSourceAddress, false)
 	sourceNetwork := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSourceNetwork, false)
-	sourcePort, err := parseFirewallPort(
+
+	sourcePortBegin, sourcePortEnd, err := parseFirewallPort(
 		propertyHelper.GetOptionalString(resourceKeyFirewallRuleSourcePort, false),
 	)
 	if err != nil {
 		return err
 	}
+
+	sourcePorts := parsePortList(
+		propertyHelper.data.Get(resourceKeyFirewallRuleSourcePorts).([]interface{}),
+	)
+
 	if sourceAddress != nil {
 		log.Printf("Rule will match source address '%s'.", *sourceAddress)
-		configuration.MatchSourceAddressAndPort(*sourceAddress, sourcePort) // Port ranges not supported yet.
+
+		switch {
+		case sourcePortEnd != nil:
+			configuration.MatchSourceAddressAndPortRange(*sourceAddress, *sourcePortBegin, *sourcePortEnd)
+		case len(sourcePorts) > 0:
+			configuration.MatchSourceAddressAndPortList(*sourceAddress, sourcePorts)
+		default:
+			configuration.MatchSourceAddressAndPort(*sourceAddress, sourcePortBegin)
+		}
 	} else if sourceNetwork != nil {
 		log.Printf("Rule will match source network '%s'.", *sourceNetwork)
 
@@ -317,10 +464,23 @@ func configureSourceScope(propertyHelper resourcePropertyHelper, configuration *
 			)
 		}
 
-		configuration.MatchSourceNetworkAndPort(baseAddress, prefixSize, sourcePort)
-	} else if sourcePort != nil {
-		log.Printf("Rule will match any source address with port %d.", *sourcePort)
-		configuration.MatchAnySourceAddress(sourcePort)
+		switch {
+		case sourcePortEnd != nil:
+			configuration.MatchSourceNetworkAndPortRange(baseAddress, prefixSize, *sourcePortBegin, *sourcePortEnd)
+		case len(sourcePorts) > 0:
+			configuration.MatchSourceNetworkAndPortList(baseAddress, prefixSize, sourcePorts)
+		default:
+			configuration.MatchSourceNetworkAndPort(baseAddress, prefixSize, sourcePortBegin)
+		}
+	} else if sourcePortEnd != nil {
+		log.Printf("Rule will match any source address with ports %d-%d.", *sourcePortBegin, *sourcePortEnd)
+		configuration.MatchAnySourceAddressWithPortRange(*sourcePortBegin, *sourcePortEnd)
+	} else if len(sourcePorts) > 0 {
+		log.Printf("Rule will match any source address with ports %v.", sourcePorts)
+		configuration.MatchAnySourceAddressWithPortList(sourcePorts)
+	} else if sourcePortBegin != nil {
+		log.Printf("Rule will match any source address with port %d.", *sourcePortBegin)
+		configuration.MatchAnySourceAddress(sourcePortBegin)
 	} else {
 		log.Print("Rule will match any source address and port.")
 		configuration.MatchAnySource()
@@ -333,15 +493,28 @@ func configureDestinationScope(propertyHelper resourcePropertyHelper, configurat
 	destinationNetwork := propertyHelper.GetOptionalString(resourceKeyFirewallRuleDestinationNetwork, false)
 	destinationAddress := propertyHelper.GetOptionalString(resourceKeyFirewallRuleDestinationAddress, false)
 
-	destinationPort, err := parseFirewallPort(
+	destinationPortBegin, destinationPortEnd, err := parseFirewallPort(
 		propertyHelper.GetOptionalString(resourceKeyFirewallRuleDestinationPort, false),
 	)
 	if err != nil {
 		return err
 	}
+
+	destinationPorts := parsePortList(
+		propertyHelper.data.Get(resourceKeyFirewallRuleDestinationPorts).([]interface{}),
+	)
+
 	if destinationAddress != nil {
 		log.Printf("Rule will match destination address '%s'.", *destinationAddress)
-		configuration.MatchDestinationAddressAndPort(*destinationAddress, destinationPort) // Port ranges not supported yet.
+
+		switch {
+		case destinationPortEnd != nil:
+			configuration.MatchDestinationAddressAndPortRange(*destinationAddress, *destinationPortBegin, *destinationPortEnd)
+		case len(destinationPorts) > 0:
+			configuration.MatchDestinationAddressAndPortList(*destinationAddress, destinationPorts)
+		default:
+			configuration.MatchDestinationAddressAndPort(*destinationAddress, destinationPortBegin)
+		}
 	} else if destinationNetwork != nil {
 		log.Printf("Rule will match destination network '%s'.", *destinationNetwork)
 
@@ -353,10 +526,23 @@ func configureDestinationScope(propertyHelper resourcePropertyHelper, configurat
 			)
 		}
 
-		configuration.MatchDestinationNetworkAndPort(baseAddress, prefixSize, destinationPort)
-	} else if destinationPort != nil {
-		log.Printf("Rule will match any destination address with port %d.", *destinationPort)
-		configuration.MatchAnyDestinationAddress(destinationPort)
+		switch {
+		case destinationPortEnd != nil:
+			configuration.MatchDestinationNetworkAndPortRange(baseAddress, prefixSize, *destinationPortBegin, *destinationPortEnd)
+		case len(destinationPorts) > 0:
+			configuration.MatchDestinationNetworkAndPortList(baseAddress, prefixSize, destinationPorts)
+		default:
+			configuration.MatchDestinationNetworkAndPort(baseAddress, prefixSize, destinationPortBegin)
+		}
+	} else if destinationPortEnd != nil {
+		log.Printf("Rule will match any destination address with ports %d-%d.", *destinationPortBegin, *destinationPortEnd)
+		configuration.MatchAnyDestinationAddressWithPortRange(*destinationPortBegin, *destinationPortEnd)
+	} else if len(destinationPorts) > 0 {
+		log.Printf("Rule will match any destination address with ports %v.", destinationPorts)
+		configuration.MatchAnyDestinationAddressWithPortList(destinationPorts)
+	} else if destinationPortBegin != nil {
+		log.Printf("Rule will match any destination address with port %d.", *destinationPortBegin)
+		configuration.MatchAnyDestinationAddress(destinationPortBegin)
 	} else {
 		log.Print("Rule will match any destination address and port.")
 		configuration.MatchAnyDestination()
@@ -365,6 +551,56 @@ func configureDestinationScope(propertyHelper resourcePropertyHelper, configurat
 	return nil
 }
 
+// isICMPProtocol determines whether the specified (already-uppercased) protocol name is ICMP or ICMPv6.
+func isICMPProtocol(protocol string) bool {
+	switch protocol {
+	case "ICMP", "ICMPV6":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateNoPortsForICMP ensures that source_port / destination_port (and their list equivalents) are not
+// specified for a rule whose protocol is 'icmp' or 'icmpv6', since ICMP rules match on type/code rather than port.
+func validateNoPortsForICMP(propertyHelper resourcePropertyHelper) error {
+	if propertyHelper.GetOptionalString(resourceKeyFirewallRuleSourcePort, false) != nil ||
+		propertyHelper.GetOptionalString(resourceKeyFirewallRuleDestinationPort, false) != nil ||
+		len(propertyHelper.data.Get(resourceKeyFirewallRuleSourcePorts).([]interface{})) > 0 ||
+		len(propertyHelper.data.Get(resourceKeyFirewallRuleDestinationPorts).([]interface{})) > 0 {
+		return fmt.Errorf(
+			"'%s', '%s', '%s', and '%s' cannot be used when protocol is 'icmp' or 'icmpv6' (use '%s' / '%s' instead)",
+			resourceKeyFirewallRuleSourcePort, resourceKeyFirewallRuleSourcePorts,
+			resourceKeyFirewallRuleDestinationPort, resourceKeyFirewallRuleDestinationPorts,
+			resourceKeyFirewallRuleICMPType, resourceKeyFirewallRuleICMPCode,
+		)
+	}
+
+	return nil
+}
+
+// configureICMP sets the ICMP type/code to be matched by a rule whose protocol is 'icmp' or 'icmpv6'.
+// A value of -1 (the default) matches any type or code. The code is only matched together with a type: when
+// icmp_type is -1, the rule matches any type and any code, whatever icmp_code says.
+func configureICMP(propertyHelper resourcePropertyHelper, configuration *compute.FirewallRuleConfiguration) {
+	icmpType := propertyHelper.data.Get(resourceKeyFirewallRuleICMPType).(int)
+	icmpCode := propertyHelper.data.Get(resourceKeyFirewallRuleICMPCode).(int)
+
+	switch {
+	case icmpType != -1 && icmpCode != -1:
+		log.Printf("Rule will match ICMP type %d, code %d.", icmpType, icmpCode)
+		configuration.MatchICMPTypeAndCode(icmpType, icmpCode)
+
+	case icmpType != -1:
+		log.Printf("Rule will match ICMP type %d (any code).", icmpType)
+		configuration.MatchICMPType(icmpType)
+
+	default:
+		log.Print("Rule will match any ICMP type and code.")
+		configuration.MatchAnyICMP()
+	}
+}
+
 func normalizeFirewallRuleAction(action string) string {
 	switch strings.ToLower(action) {
 	case "accept":
@@ -387,17 +623,108 @@ func normalizeFirewallRuleAction(action string) string {
 	}
 }
 
-func parseFirewallPort(port *string) (*int, error) {
-	if port == nil || *port == "any" {
-		return nil, nil
+// parseFirewallPort parses a source or destination port specification, which may be "any", a single port number, or a port range ("BeginPort-EndPort").
+//
+// If a single port is specified, only beginPort will be non-nil. If a range is specified, both beginPort and endPort will be non-nil.
+func parseFirewallPort(port *string) (beginPort *int, endPort *int, err error) {
+	if port == nil || *port == matchAny {
+		return
+	}
+
+	if !strings.Contains(*port, "-") {
+		parsedPort, parseErr := strconv.Atoi(*port)
+		if parseErr != nil {
+			err = parseErr
+
+			return
+		}
+
+		beginPort = &parsedPort
+
+		return
 	}
 
-	parsedPort, err := strconv.Atoi(*port)
-	if err != nil {
-		return nil, err
+	beginPortRaw, endPortRaw := parsePortRange(port)
+	if endPortRaw == nil {
+		err = fmt.Errorf("'%s' is not a valid port range (expected 'BeginPort-EndPort')", *port)
+
+		return
+	}
+
+	parsedBeginPort, parseErr := strconv.Atoi(beginPortRaw)
+	if parseErr != nil {
+		err = fmt.Errorf("'%s' is not a valid port range: %s", *port, parseErr)
+
+		return
+	}
+
+	parsedEndPort, parseErr := strconv.Atoi(*endPortRaw)
+	if parseErr != nil {
+		err = fmt.Errorf("'%s' is not a valid port range: %s", *port, parseErr)
+
+		return
+	}
+
+	if parsedBeginPort < 1 || parsedBeginPort > 65535 || parsedEndPort < 1 || parsedEndPort > 65535 {
+		err = fmt.Errorf("'%s' is not a valid port range (ports must be between 1 and 65535)", *port)
+
+		return
+	}
+	if parsedBeginPort > parsedEndPort {
+		err = fmt.Errorf("'%s' is not a valid port range (begin port must be less than or equal to end port)", *port)
+
+		return
+	}
+
+	beginPort = &parsedBeginPort
+	endPort = &parsedEndPort
+
+	return
+}
+
+// parsePortList converts the raw values of a source_ports / destination_ports list attribute into a slice of port numbers.
+func parsePortList(rawPorts []interface{}) []int {
+	ports := make([]int, len(rawPorts))
+	for index, rawPort := range rawPorts {
+		ports[index] = rawPort.(int)
+	}
+
+	return ports
+}
+
+// validateFirewallRulePortNumber is a schema.ValidateFunc that ensures a port number falls within the valid range (1-65535).
+func validateFirewallRulePortNumber(value interface{}, key string) (warnings []string, errors []error) {
+	port := value.(int)
+	if port < 1 || port > 65535 {
+		errors = append(errors, fmt.Errorf("'%s' must be between 1 and 65535 (got %d)", key, port))
+	}
+
+	return
+}
+
+// validateFirewallRuleProtocol is a schema.ValidateFunc that ensures 'protocol' is one of the values supported by CloudControl.
+func validateFirewallRuleProtocol(value interface{}, key string) (warnings []string, errors []error) {
+	switch strings.ToLower(value.(string)) {
+	case "tcp", "udp", "icmp", "icmpv6", "ip":
+		return
+	default:
+		errors = append(errors, fmt.Errorf(
+			"'%s' must be one of 'tcp', 'udp', 'icmp', 'icmpv6', or 'ip' (got '%s')", key, value.(string),
+		))
+
+		return
+	}
+}
+
+// validateFirewallRuleICMPTypeOrCode is a schema.ValidateFunc that ensures an ICMP type or code falls within the
+// valid range (0-255), or is -1 to match any type or code.
+func validateFirewallRuleICMPTypeOrCode(value interface{}, key string) (warnings []string, errors []error) {
+	typeOrCode := value.(int)
+	if typeOrCode != -1 && (typeOrCode < 0 || typeOrCode > 255) {
+		errors = append(errors, fmt.Errorf("'%s' must be between 0 and 255, or -1 to match any (got %d)", key, typeOrCode))
 	}
 
-	return &parsedPort, nil
+	return
 }
 
 func parsePortRange(portRange *string) (beginPort string, endPort *string) {
@@ -0,0 +1,381 @@
+package ddcloud
+
+import (
+	"fmt"
+	"github.com/DimensionDataResearch/go-dd-cloud-compute/compute"
+	"github.com/hashicorp/terraform/helper/schema"
+	"log"
+	"strings"
+)
+
+const (
+	resourceKeyFirewallRuleSetNetworkDomainID    = "networkdomain"
+	resourceKeyFirewallRuleSetName               = "name"
+	resourceKeyFirewallRuleSetEnabled            = "enabled"
+	resourceKeyFirewallRuleSetIPVersion          = "ip_version"
+	resourceKeyFirewallRuleSetSourceAddress      = "source_address"
+	resourceKeyFirewallRuleSetSourceNetwork      = "source_network"
+	resourceKeyFirewallRuleSetDestinationAddress = "destination_address"
+	resourceKeyFirewallRuleSetDestinationNetwork = "destination_network"
+	resourceKeyFirewallRuleSetAllow              = "allow"
+	resourceKeyFirewallRuleSetDeny               = "deny"
+	resourceKeyFirewallRuleSetMatchProtocol      = "protocol"
+	resourceKeyFirewallRuleSetMatchPorts         = "ports"
+	resourceKeyFirewallRuleSetRuleIDs            = "rule_ids"
+)
+
+// resourceFirewallRuleSet defines the ddcloud_firewall_rule_set resource.
+//
+// Modeled on google_compute_firewall's allow {} / deny {} blocks, it lets a single resource expand to one
+// CloudControl firewall rule per (protocol, port) tuple, sharing a common name prefix and scope, and manages
+// them as a single unit. allow and deny cannot be mixed on the same resource.
+func resourceFirewallRuleSet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallRuleSetCreate,
+		Read:   resourceFirewallRuleSetRead,
+		Delete: resourceFirewallRuleSetDelete,
+
+		Schema: map[string]*schema.Schema{
+			resourceKeyFirewallRuleSetNetworkDomainID: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Required:    true,
+				Description: "The Id of the network domain to which the firewall rules apply",
+			},
+			resourceKeyFirewallRuleSetName: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Required:    true,
+				Description: "The name prefix shared by the firewall rules created from this rule set",
+			},
+			resourceKeyFirewallRuleSetEnabled: &schema.Schema{
+				Type:        schema.TypeBool,
+				ForceNew:    true,
+				Optional:    true,
+				Default:     true,
+				Description: "Are the firewall rules in this rule set enabled",
+			},
+			resourceKeyFirewallRuleSetIPVersion: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Required:    true,
+				Description: "The IP version to which the firewall rules apply",
+			},
+			resourceKeyFirewallRuleSetSourceAddress: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The source IP address to be matched by the firewall rules",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetSourceNetwork,
+				},
+			},
+			resourceKeyFirewallRuleSetSourceNetwork: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The source IP network to be matched by the firewall rules",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetSourceAddress,
+				},
+			},
+			resourceKeyFirewallRuleSetDestinationAddress: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The destination IP address to be matched by the firewall rules",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetDestinationNetwork,
+				},
+			},
+			resourceKeyFirewallRuleSetDestinationNetwork: &schema.Schema{
+				Type:        schema.TypeString,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "The destination IP network to be matched by the firewall rules",
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetDestinationAddress,
+				},
+			},
+			resourceKeyFirewallRuleSetAllow: &schema.Schema{
+				Type:        schema.TypeSet,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "A protocol / port combination for which traffic is accepted",
+				Elem:        firewallRuleSetMatchResource(),
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetDeny,
+				},
+			},
+			resourceKeyFirewallRuleSetDeny: &schema.Schema{
+				Type:        schema.TypeSet,
+				ForceNew:    true,
+				Optional:    true,
+				Description: "A protocol / port combination for which traffic is dropped",
+				Elem:        firewallRuleSetMatchResource(),
+				ConflictsWith: []string{
+					resourceKeyFirewallRuleSetAllow,
+				},
+			},
+			resourceKeyFirewallRuleSetRuleIDs: &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The Ids of the underlying firewall rules created by this rule set",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func firewallRuleSetMatchResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			resourceKeyFirewallRuleSetMatchProtocol: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The protocol to match ('tcp' or 'udp')",
+			},
+			resourceKeyFirewallRuleSetMatchPorts: &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				Description: "The ports (or port ranges, e.g. '8000-8099') to match",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+// Create a firewall rule set resource, expanding each allow/deny block into one firewall rule per (protocol, port) tuple.
+func resourceFirewallRuleSetCreate(data *schema.ResourceData, provider interface{}) error {
+	networkDomainID := data.Get(resourceKeyFirewallRuleSetNetworkDomainID).(string)
+	name := data.Get(resourceKeyFirewallRuleSetName).(string)
+
+	log.Printf("Create firewall rule set '%s' in network domain '%s'.", name, networkDomainID)
+
+	configurations, err := expandFirewallRuleSet(data, networkDomainID)
+	if err != nil {
+		return err
+	}
+
+	providerState := provider.(*providerState)
+	apiClient := providerState.Client()
+
+	domainLock := providerState.GetDomainLock(networkDomainID, "resourceFirewallRuleSetCreate('%s')", name)
+	domainLock.Lock()
+	defer domainLock.Unlock()
+
+	ruleIDs := make([]string, 0, len(configurations))
+
+	for _, configuration := range configurations {
+		ruleID, createErr := apiClient.CreateFirewallRule(configuration)
+		if createErr != nil {
+			err = createErr
+
+			break
+		}
+
+		// Record the Id as soon as the rule exists, before waiting for deploy, so a failed wait still rolls it back.
+		ruleIDs = append(ruleIDs, ruleID)
+
+		_, err = apiClient.WaitForDeploy(compute.ResourceTypeFirewallRule, ruleID, resourceCreateTimeoutFirewallRule)
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		log.Printf("Failed to create firewall rule set '%s' in network domain '%s' (%s); rolling back %d rule(s) already created.",
+			name, networkDomainID, err, len(ruleIDs),
+		)
+
+		for _, ruleID := range ruleIDs {
+			if deleteErr := apiClient.DeleteFirewallRule(ruleID); deleteErr != nil {
+				log.Printf("Failed to roll back firewall rule '%s': %s", ruleID, deleteErr)
+			}
+		}
+
+		return err
+	}
+
+	data.SetId(fmt.Sprintf("%s-%s", networkDomainID, name))
+	data.Set(resourceKeyFirewallRuleSetRuleIDs, ruleIDs)
+
+	return nil
+}
+
+// Read a firewall rule set resource.
+func resourceFirewallRuleSetRead(data *schema.ResourceData, provider interface{}) error {
+	name := data.Get(resourceKeyFirewallRuleSetName).(string)
+	networkDomainID := data.Get(resourceKeyFirewallRuleSetNetworkDomainID).(string)
+
+	log.Printf("Read firewall rule set '%s' in network domain '%s'.", name, networkDomainID)
+
+	apiClient := provider.(*providerState).Client()
+
+	ruleIDs := stringListFromData(data, resourceKeyFirewallRuleSetRuleIDs)
+	existingRuleIDs := make([]string, 0, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		rule, err := apiClient.GetFirewallRule(ruleID)
+		if err != nil {
+			return err
+		}
+		if rule == nil {
+			log.Printf("Firewall rule '%s' (part of rule set '%s') has been deleted.", ruleID, name)
+
+			continue
+		}
+
+		existingRuleIDs = append(existingRuleIDs, ruleID)
+	}
+
+	if len(existingRuleIDs) == 0 {
+		log.Printf("Firewall rule set '%s' in network domain '%s' has been deleted.", name, networkDomainID)
+
+		data.SetId("")
+
+		return nil
+	}
+
+	data.Set(resourceKeyFirewallRuleSetRuleIDs, existingRuleIDs)
+
+	return nil
+}
+
+// Delete a firewall rule set resource, along with all the firewall rules it owns.
+func resourceFirewallRuleSetDelete(data *schema.ResourceData, provider interface{}) error {
+	name := data.Get(resourceKeyFirewallRuleSetName).(string)
+	networkDomainID := data.Get(resourceKeyFirewallRuleSetNetworkDomainID).(string)
+
+	log.Printf("Delete firewall rule set '%s' in network domain '%s'.", name, networkDomainID)
+
+	providerState := provider.(*providerState)
+	apiClient := providerState.Client()
+
+	domainLock := providerState.GetDomainLock(networkDomainID, "resourceFirewallRuleSetDelete('%s')", name)
+	domainLock.Lock()
+	defer domainLock.Unlock()
+
+	ruleIDs := stringListFromData(data, resourceKeyFirewallRuleSetRuleIDs)
+	for _, ruleID := range ruleIDs {
+		err := apiClient.DeleteFirewallRule(ruleID)
+		if err != nil {
+			return err
+		}
+
+		err = apiClient.WaitForDelete(compute.ResourceTypeFirewallRule, ruleID, resourceDeleteTimeoutFirewallRule)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandFirewallRuleSet expands a ddcloud_firewall_rule_set resource's allow/deny blocks into one
+// compute.FirewallRuleConfiguration per (protocol, port) tuple.
+func expandFirewallRuleSet(data *schema.ResourceData, networkDomainID string) ([]compute.FirewallRuleConfiguration, error) {
+	name := data.Get(resourceKeyFirewallRuleSetName).(string)
+	enabled := data.Get(resourceKeyFirewallRuleSetEnabled).(bool)
+	ipVersion := strings.ToUpper(data.Get(resourceKeyFirewallRuleSetIPVersion).(string))
+
+	propertyHelper := propertyHelper(data)
+	sourceAddress := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSetSourceAddress, false)
+	sourceNetwork := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSetSourceNetwork, false)
+	destinationAddress := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSetDestinationAddress, false)
+	destinationNetwork := propertyHelper.GetOptionalString(resourceKeyFirewallRuleSetDestinationNetwork, false)
+
+	action := compute.FirewallRuleActionAccept
+	matchBlocks := data.Get(resourceKeyFirewallRuleSetAllow).(*schema.Set).List()
+	if len(matchBlocks) == 0 {
+		action = compute.FirewallRuleActionDrop
+		matchBlocks = data.Get(resourceKeyFirewallRuleSetDeny).(*schema.Set).List()
+	}
+	if len(matchBlocks) == 0 {
+		return nil, fmt.Errorf("firewall rule set '%s' must specify at least one '%s' or '%s' block",
+			name, resourceKeyFirewallRuleSetAllow, resourceKeyFirewallRuleSetDeny,
+		)
+	}
+
+	configurations := make([]compute.FirewallRuleConfiguration, 0)
+	ruleIndex := 0
+	for _, matchBlockData := range matchBlocks {
+		matchBlock := matchBlockData.(map[string]interface{})
+		protocol := strings.ToUpper(matchBlock[resourceKeyFirewallRuleSetMatchProtocol].(string))
+
+		for _, rawPort := range matchBlock[resourceKeyFirewallRuleSetMatchPorts].([]interface{}) {
+			port := rawPort.(string)
+
+			portBegin, portEnd, err := parseFirewallPort(&port)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port '%s' for firewall rule set '%s': %s", port, name, err)
+			}
+
+			configuration := compute.FirewallRuleConfiguration{
+				// ruleIndex runs across every allow/deny block (not just the current one), since 2 blocks can
+				// share the same protocol and would otherwise produce colliding rule names.
+				Name:   fmt.Sprintf("%s-%s-%d", name, strings.ToLower(protocol), ruleIndex),
+				Action: action,
+				Placement: compute.FirewallRulePlacement{
+					Position: "LAST",
+				},
+				Enabled:         enabled,
+				NetworkDomainID: networkDomainID,
+				IPVersion:       ipVersion,
+				Protocol:        protocol,
+			}
+
+			// Source scope is shared across the whole rule set; the protocol/port pairing from the allow/deny
+			// block (like GCP's allow.ports) describes the destination service being matched.
+			switch {
+			case sourceAddress != nil:
+				configuration.MatchSourceAddressAndPort(*sourceAddress, nil)
+			case sourceNetwork != nil:
+				baseAddress, prefixSize, ok := parseNetworkAndPrefix(*sourceNetwork)
+				if !ok {
+					return nil, fmt.Errorf("source_network '%s' for firewall rule set '%s' is invalid (must be 'BaseAddress/PrefixSize')", *sourceNetwork, name)
+				}
+				configuration.MatchSourceNetworkAndPort(baseAddress, prefixSize, nil)
+			default:
+				configuration.MatchAnySource()
+			}
+
+			switch {
+			case destinationAddress != nil && portEnd != nil:
+				configuration.MatchDestinationAddressAndPortRange(*destinationAddress, *portBegin, *portEnd)
+			case destinationAddress != nil:
+				configuration.MatchDestinationAddressAndPort(*destinationAddress, portBegin)
+			case destinationNetwork != nil:
+				baseAddress, prefixSize, ok := parseNetworkAndPrefix(*destinationNetwork)
+				if !ok {
+					return nil, fmt.Errorf("destination_network '%s' for firewall rule set '%s' is invalid (must be 'BaseAddress/PrefixSize')", *destinationNetwork, name)
+				}
+				if portEnd != nil {
+					configuration.MatchDestinationNetworkAndPortRange(baseAddress, prefixSize, *portBegin, *portEnd)
+				} else {
+					configuration.MatchDestinationNetworkAndPort(baseAddress, prefixSize, portBegin)
+				}
+			case portEnd != nil:
+				configuration.MatchAnyDestinationAddressWithPortRange(*portBegin, *portEnd)
+			default:
+				configuration.MatchAnyDestinationAddress(portBegin)
+			}
+
+			configurations = append(configurations, configuration)
+			ruleIndex++
+		}
+	}
+
+	return configurations, nil
+}
+
+// stringListFromData reads a TypeList of strings from resource data.
+func stringListFromData(data *schema.ResourceData, key string) []string {
+	rawValues := data.Get(key).([]interface{})
+
+	values := make([]string, len(rawValues))
+	for index, rawValue := range rawValues {
+		values[index] = rawValue.(string)
+	}
+
+	return values
+}